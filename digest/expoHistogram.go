@@ -0,0 +1,237 @@
+package tdigest
+
+import (
+	"errors"
+	"math"
+)
+
+// maxScale is the finest resolution an ExpoHistogram will start at. It matches the
+// maximum scale allowed by the OpenTelemetry exponential histogram specification.
+const maxScale = 20
+
+// ExpoHistogram implements the OpenTelemetry base-2 exponential histogram model. Each
+// bucket has width base^1 where base = 2^(2^-scale), so relative bucket width is bounded
+// by the scale regardless of where a value falls. Unlike LogHistogram, an ExpoHistogram
+// does not need to know the range of values in advance: Add automatically halves the
+// resolution (downscales), coalescing adjacent buckets, whenever recording a new value
+// would otherwise need more than maxSize buckets on either side of zero. This gives a
+// fixed-memory histogram that self-tunes its resolution to whatever range is observed.
+type ExpoHistogram struct {
+	maxSize int
+	scale   int
+
+	zeroCount          uint64
+	positive, negative *expoBuckets
+}
+
+// expoBuckets is a dense array of per-bucket counts together with the bucket index of
+// counts[0]. Buckets below offset or at/above offset+len(counts) are implicitly zero.
+type expoBuckets struct {
+	offset int
+	counts []uint64
+}
+
+// NewExpoHistogram creates an empty ExpoHistogram bounded to maxSize buckets on the
+// positive side and, separately, on the negative side. maxSize defaults to 160, the
+// value recommended by the OpenTelemetry specification.
+func NewExpoHistogram(maxSize int) (*ExpoHistogram, error) {
+	if maxSize == 0 {
+		maxSize = 160
+	}
+	if maxSize < 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	return &ExpoHistogram{
+		maxSize:  maxSize,
+		scale:    maxScale,
+		positive: &expoBuckets{},
+		negative: &expoBuckets{},
+	}, nil
+}
+
+// Add records v, downscaling first if necessary to keep both the positive and negative
+// bucket counts within maxSize.
+func (h *ExpoHistogram) Add(v float64) {
+	switch {
+	case v == 0:
+		h.zeroCount++
+	case v > 0:
+		h.addToBucket(h.positive, v)
+	default:
+		h.addToBucket(h.negative, -v)
+	}
+}
+
+func (h *ExpoHistogram) addToBucket(b *expoBuckets, v float64) {
+	idx := expoIndex(v, h.scale)
+	low, high := idx, idx
+	if n := len(b.counts); n > 0 {
+		if b.offset < low {
+			low = b.offset
+		}
+		if end := b.offset + n - 1; end > high {
+			high = end
+		}
+	}
+	if k := downscaleAmount(low, high, h.maxSize); k > 0 {
+		h.downscale(k)
+		idx = expoIndex(v, h.scale)
+	}
+	b.increment(idx)
+}
+
+// downscaleAmount returns how many times the bucket range [low, high] must be halved to
+// fit within maxSize buckets. It starts from k = ceil(log2(span / maxSize)) and adds one
+// more halving while the shifted range, depending on where low and high fall, still
+// spans more than maxSize buckets.
+func downscaleAmount(low, high, maxSize int) int {
+	span := high - low + 1
+	if span <= maxSize {
+		return 0
+	}
+	k := int(math.Ceil(math.Log2(float64(span) / float64(maxSize))))
+	for k < 64 && (high>>uint(k))-(low>>uint(k))+1 > maxSize {
+		k++
+	}
+	return k
+}
+
+// expoIndex returns the index of the bucket that v, which must be positive, falls into
+// at the given scale.
+func expoIndex(v float64, scale int) int {
+	return int(math.Ceil(math.Log2(v)*math.Pow(2, float64(scale)))) - 1
+}
+
+// downscale halves the resolution k times, coalescing pairs (then groups of 4, 8, ...)
+// of adjacent buckets on both the positive and negative side.
+func (h *ExpoHistogram) downscale(k int) {
+	if k <= 0 {
+		return
+	}
+	h.positive.downscale(k)
+	h.negative.downscale(k)
+	h.scale -= k
+}
+
+// Scale returns the current scale. Bucket boundaries are powers of 2^(2^-Scale()).
+func (h *ExpoHistogram) Scale() int {
+	return h.scale
+}
+
+// Offset returns the index of Positive()[0], i.e. the lowest populated positive bucket.
+func (h *ExpoHistogram) Offset() int {
+	return h.positive.offset
+}
+
+// NegativeOffset returns the index of Negative()[0], i.e. the lowest populated negative
+// bucket (negative buckets are indexed by the magnitude of the values they hold).
+func (h *ExpoHistogram) NegativeOffset() int {
+	return h.negative.offset
+}
+
+// Positive returns the bucket counts for positive values, starting at Offset().
+func (h *ExpoHistogram) Positive() []uint64 {
+	return h.positive.counts
+}
+
+// Negative returns the bucket counts for negative values, starting at NegativeOffset().
+func (h *ExpoHistogram) Negative() []uint64 {
+	return h.negative.counts
+}
+
+// ZeroCount returns the number of values recorded that were exactly zero.
+func (h *ExpoHistogram) ZeroCount() uint64 {
+	return h.zeroCount
+}
+
+// Merge folds other into h. If the two histograms have different scales, the finer
+// (higher-scale) one is downscaled first so that both sides are compared at the coarser
+// of the two scales; other is left unmodified.
+func (h *ExpoHistogram) Merge(other *ExpoHistogram) {
+	target := h.scale
+	if other.scale < target {
+		target = other.scale
+	}
+	if h.scale > target {
+		h.downscale(h.scale - target)
+	}
+
+	op, on := other.positive, other.negative
+	if other.scale > target {
+		op = op.downscaled(other.scale - target)
+		on = on.downscaled(other.scale - target)
+	}
+
+	h.zeroCount += other.zeroCount
+	h.positive.merge(op)
+	h.negative.merge(on)
+	h.fitToMaxSize()
+}
+
+// fitToMaxSize downscales h, if necessary, until both bucket arrays are within maxSize.
+func (h *ExpoHistogram) fitToMaxSize() {
+	pos, neg := h.positive, h.negative
+	k := downscaleAmount(pos.offset, pos.offset+len(pos.counts)-1, h.maxSize)
+	if n := downscaleAmount(neg.offset, neg.offset+len(neg.counts)-1, h.maxSize); n > k {
+		k = n
+	}
+	h.downscale(k)
+}
+
+func (b *expoBuckets) increment(idx int) {
+	b.add(idx, 1)
+}
+
+func (b *expoBuckets) add(idx int, n uint64) {
+	if n == 0 {
+		return
+	}
+	if len(b.counts) == 0 {
+		b.offset = idx
+		b.counts = []uint64{n}
+		return
+	}
+	if idx < b.offset {
+		grown := make([]uint64, b.offset-idx+len(b.counts))
+		copy(grown[b.offset-idx:], b.counts)
+		b.offset = idx
+		b.counts = grown
+	} else if idx >= b.offset+len(b.counts) {
+		grown := make([]uint64, idx-b.offset+1)
+		copy(grown, b.counts)
+		b.counts = grown
+	}
+	b.counts[idx-b.offset] += n
+}
+
+func (b *expoBuckets) merge(other *expoBuckets) {
+	for i, c := range other.counts {
+		b.add(other.offset+i, c)
+	}
+}
+
+func (b *expoBuckets) clone() *expoBuckets {
+	counts := make([]uint64, len(b.counts))
+	copy(counts, b.counts)
+	return &expoBuckets{offset: b.offset, counts: counts}
+}
+
+func (b *expoBuckets) downscaled(k int) *expoBuckets {
+	c := b.clone()
+	c.downscale(k)
+	return c
+}
+
+func (b *expoBuckets) downscale(k int) {
+	if k <= 0 || len(b.counts) == 0 {
+		return
+	}
+	newOffset := b.offset >> k
+	newEnd := (b.offset + len(b.counts) - 1) >> k
+	newCounts := make([]uint64, newEnd-newOffset+1)
+	for i, c := range b.counts {
+		newCounts[((b.offset+i)>>k)-newOffset] += c
+	}
+	b.offset = newOffset
+	b.counts = newCounts
+}
@@ -0,0 +1,75 @@
+package tdigest
+
+import (
+	"testing"
+)
+
+func TestExpoHistogram_Add(t *testing.T) {
+	hist, err := NewExpoHistogram(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		hist.Add(float64(i + 1))
+	}
+
+	var total uint64
+	for _, k := range hist.Positive() {
+		total += k
+	}
+	if total != 10000 {
+		t.Errorf("expected 10000 observations, got %d", total)
+	}
+	if len(hist.Positive()) > 160 {
+		t.Errorf("expected at most 160 buckets, got %d", len(hist.Positive()))
+	}
+}
+
+func TestExpoHistogram_NegativeAndZero(t *testing.T) {
+	hist, _ := NewExpoHistogram(0)
+
+	hist.Add(0)
+	hist.Add(0)
+	hist.Add(-5)
+	hist.Add(5)
+
+	if hist.ZeroCount() != 2 {
+		t.Errorf("expected ZeroCount() == 2, got %d", hist.ZeroCount())
+	}
+	var pos, neg uint64
+	for _, k := range hist.Positive() {
+		pos += k
+	}
+	for _, k := range hist.Negative() {
+		neg += k
+	}
+	if pos != 1 || neg != 1 {
+		t.Errorf("expected one positive and one negative observation, got %d, %d", pos, neg)
+	}
+}
+
+func TestExpoHistogram_Merge(t *testing.T) {
+	a, _ := NewExpoHistogram(0)
+	b, _ := NewExpoHistogram(0)
+
+	for i := 0; i < 1000; i++ {
+		a.Add(float64(i + 1))
+	}
+	for i := 0; i < 1000000; i++ {
+		b.Add(float64(i + 1))
+	}
+
+	a.Merge(b)
+
+	var total uint64
+	for _, k := range a.Positive() {
+		total += k
+	}
+	if total != 1001000 {
+		t.Errorf("expected 1001000 observations after merge, got %d", total)
+	}
+	if len(a.Positive()) > 160 {
+		t.Errorf("expected merged histogram to still fit in 160 buckets, got %d", len(a.Positive()))
+	}
+}
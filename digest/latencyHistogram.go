@@ -0,0 +1,98 @@
+package tdigest
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencySubBucketBits is the number of linearly-spaced sub-buckets within each
+	// super-bucket, expressed as a power of two.
+	latencySubBucketBits = 6
+	latencyNumSubBuckets = 1 << latencySubBucketBits
+
+	// latencyNumSuperBuckets covers every bit length a non-negative int64 nanosecond
+	// duration can have: bit lengths 0 through latencySubBucketBits share super-bucket
+	// 0 (each nanosecond value gets its own sub-bucket there), and every bit length
+	// above that gets its own super-bucket up to 63, the largest bit length
+	// bits.Len64 can return for a non-negative int64.
+	latencyNumSuperBuckets = 64 - latencySubBucketBits
+)
+
+// LatencyHistogram is a fixed-size, allocation-free histogram for non-negative
+// time.Duration values such as request latencies. It is modeled on the super-bucket/
+// sub-bucket scheme used by the Go runtime's internal time histogram: a duration is
+// assigned to a super-bucket by its bit length, found in O(1) with bits.Len64, and each
+// super-bucket is split into latencyNumSubBuckets linearly-spaced sub-buckets. The
+// result is log-linear resolution using only integer shifts and masks in Record's hot
+// path, unlike the floating-point approxLog2 that LogHistogram.Add relies on.
+//
+// The zero value is an empty, ready-to-use histogram.
+type LatencyHistogram struct {
+	counts    [latencyNumSuperBuckets * latencyNumSubBuckets]uint64
+	underflow uint64
+}
+
+// Record adds d to the histogram. Negative durations cannot be bucketed by bit length,
+// so they are counted separately; see Underflow.
+func (h *LatencyHistogram) Record(d time.Duration) {
+	ns := int64(d)
+	if ns < 0 {
+		atomic.AddUint64(&h.underflow, 1)
+		return
+	}
+	super, sub := latencyBucketOf(uint64(ns))
+	atomic.AddUint64(&h.counts[super*latencyNumSubBuckets+sub], 1)
+}
+
+// latencyBucketOf returns the super- and sub-bucket that ns, a non-negative nanosecond
+// count, falls into.
+func latencyBucketOf(ns uint64) (super, sub uint) {
+	bit := uint(bits.Len64(ns))
+	if bit <= latencySubBucketBits {
+		return 0, uint(ns)
+	}
+	base := uint64(1) << (bit - 1)
+	shift := bit - 1 - latencySubBucketBits
+	return bit - latencySubBucketBits, uint((ns - base) >> shift)
+}
+
+// latencyBucketUpperBound returns the largest nanosecond value, inclusive, that falls
+// into bucket (super, sub). It is the inverse of latencyBucketOf.
+func latencyBucketUpperBound(super, sub uint) uint64 {
+	if super == 0 {
+		return uint64(sub)
+	}
+	bit := super + latencySubBucketBits
+	base := uint64(1) << (bit - 1)
+	shift := bit - 1 - latencySubBucketBits
+	return base + (uint64(sub)+1)<<shift - 1
+}
+
+// Count returns the number of recorded values in the given super- and sub-bucket, and
+// whether that combination is a valid bucket.
+func (h *LatencyHistogram) Count(super, sub uint) (uint64, bool) {
+	if super >= latencyNumSuperBuckets || sub >= latencyNumSubBuckets {
+		return 0, false
+	}
+	return atomic.LoadUint64(&h.counts[super*latencyNumSubBuckets+sub]), true
+}
+
+// Underflow returns the number of negative durations recorded.
+func (h *LatencyHistogram) Underflow() uint64 {
+	return atomic.LoadUint64(&h.underflow)
+}
+
+// Buckets returns the upper bound, in seconds, of every (super, sub) bucket in index
+// order (super-major, matching Count's iteration order), suitable for client_golang's
+// HistogramOpts.Buckets or for building a Prometheus native histogram's boundaries.
+func (h *LatencyHistogram) Buckets() []float64 {
+	bounds := make([]float64, latencyNumSuperBuckets*latencyNumSubBuckets)
+	for super := uint(0); super < latencyNumSuperBuckets; super++ {
+		for sub := uint(0); sub < latencyNumSubBuckets; sub++ {
+			bounds[super*latencyNumSubBuckets+sub] = float64(latencyBucketUpperBound(super, sub)) / 1e9
+		}
+	}
+	return bounds
+}
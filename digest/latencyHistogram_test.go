@@ -0,0 +1,63 @@
+package tdigest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_Record(t *testing.T) {
+	var hist LatencyHistogram
+
+	hist.Record(100 * time.Nanosecond)
+	hist.Record(-5 * time.Nanosecond)
+	hist.Record(2 * time.Second)
+
+	if hist.Underflow() != 1 {
+		t.Errorf("expected Underflow() == 1, got %d", hist.Underflow())
+	}
+
+	var total uint64
+	for super := uint(0); super < latencyNumSuperBuckets; super++ {
+		for sub := uint(0); sub < latencyNumSubBuckets; sub++ {
+			count, ok := hist.Count(super, sub)
+			if !ok {
+				t.Fatalf("Count(%d, %d) reported invalid for an in-range bucket", super, sub)
+			}
+			total += count
+		}
+	}
+	if total != 2 {
+		t.Errorf("expected 2 recorded values across valid buckets, got %d", total)
+	}
+
+	if _, ok := hist.Count(latencyNumSuperBuckets, 0); ok {
+		t.Error("expected Count with out-of-range super-bucket to report invalid")
+	}
+}
+
+func TestLatencyHistogram_BucketOfMonotonic(t *testing.T) {
+	prevSuper, prevSub := latencyBucketOf(0)
+	for ns := uint64(1); ns < 1<<20; ns++ {
+		super, sub := latencyBucketOf(ns)
+		if super < prevSuper || (super == prevSuper && sub < prevSub) {
+			t.Fatalf("bucket(%d) = (%d, %d) is not >= previous bucket (%d, %d)", ns, super, sub, prevSuper, prevSub)
+		}
+		if upper := latencyBucketUpperBound(super, sub); upper < ns {
+			t.Fatalf("latencyBucketUpperBound(%d, %d) = %d is below the value %d that maps to it", super, sub, upper, ns)
+		}
+		prevSuper, prevSub = super, sub
+	}
+}
+
+func TestLatencyHistogram_Buckets(t *testing.T) {
+	var hist LatencyHistogram
+	bounds := hist.Buckets()
+	if len(bounds) != latencyNumSuperBuckets*latencyNumSubBuckets {
+		t.Fatalf("expected %d bucket boundaries, got %d", latencyNumSuperBuckets*latencyNumSubBuckets, len(bounds))
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			t.Errorf("expected strictly increasing bucket boundaries, bounds[%d]=%.9f <= bounds[%d]=%.9f", i, bounds[i], i-1, bounds[i-1])
+		}
+	}
+}
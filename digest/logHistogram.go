@@ -4,15 +4,27 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sync/atomic"
+	"time"
 )
 
 // LogHistogram has bins that are very nearly logarithmically spaced from a min to a max value.
+//
+// Add, RecordDuration, AddHistograms and Reset all update bucket counts with atomic
+// operations, so a single LogHistogram can be shared across goroutines without any
+// external locking. GetCounts and Snapshot may be called concurrently with recording;
+// Snapshot is the one to use when the result must not change underneath the caller.
 type LogHistogram struct {
 	min, max             float64
 	logFactor, logOffset float64
-	count                []uint32
+	count                []uint64
 }
 
+// logHistogramMaxBins is the ceiling NewLogHistogram enforces on the number of bins a
+// (min, max, epsilonFactor) combination may produce. UnmarshalBinary and UnmarshalJSON
+// enforce the same ceiling on decoded data.
+const logHistogramMaxBins = 10000
+
 func NewLogHistogram(min, max, epsilonFactor float64) (*LogHistogram, error) {
 	if epsilonFactor == 0 {
 		epsilonFactor = 0.1
@@ -40,12 +52,12 @@ func NewLogHistogram(min, max, epsilonFactor float64) (*LogHistogram, error) {
 		logOffset: approxLog2(min) * tmp,
 	}
 	binCount := r.BucketIndex(max) + 1
-	if binCount > 10000 {
+	if binCount > logHistogramMaxBins {
 		return nil, errors.New(
 			fmt.Sprintf("Excessive number of bins %d resulting from min,max = %.2g, %.2g",
 				binCount, min, max))
 	}
-	r.count = make([]uint32, binCount)
+	r.count = make([]uint64, binCount)
 	return r, nil
 }
 
@@ -87,8 +99,16 @@ func (hist LogHistogram) LowerBound(k int) float64 {
 	return pow2((float64(k) + hist.logOffset) / hist.logFactor)
 }
 
+// Add increments the bucket that v falls in. It may be called concurrently from
+// multiple goroutines, and concurrently with RecordDuration, AddHistograms and Reset.
 func (hist LogHistogram) Add(v float64) {
-	hist.count[hist.bucket(v)]++
+	atomic.AddUint64(&hist.count[hist.bucket(v)], 1)
+}
+
+// RecordDuration is a convenience wrapper around Add for histograms that measure
+// durations in seconds, such as request latencies. It is safe to call concurrently.
+func (hist LogHistogram) RecordDuration(d time.Duration) {
+	hist.Add(d.Seconds())
 }
 
 func (hist LogHistogram) GetBounds() []float64 {
@@ -99,17 +119,40 @@ func (hist LogHistogram) GetBounds() []float64 {
 	return r
 }
 
-func (hist LogHistogram) GetCounts() []uint32 {
+// GetCounts returns the live bucket counts. Callers that need a value that is stable
+// in the presence of concurrent recording should use Snapshot instead.
+func (hist LogHistogram) GetCounts() []uint64 {
 	return hist.count
 }
 
+// Snapshot returns a copy of the bucket counts, taken with atomic loads so that it is
+// safe to call while other goroutines are recording into the histogram. The result
+// will not be mutated by later calls to Add, so it is suitable for publishing to a
+// Prometheus or OTLP scraper.
+func (hist LogHistogram) Snapshot() []uint64 {
+	r := make([]uint64, len(hist.count))
+	for i := range hist.count {
+		r[i] = atomic.LoadUint64(&hist.count[i])
+	}
+	return r
+}
+
+// Reset zeroes all bucket counts. It is safe to call concurrently with Add.
+func (hist LogHistogram) Reset() {
+	for i := range hist.count {
+		atomic.StoreUint64(&hist.count[i], 0)
+	}
+}
+
+// AddHistograms merges others into hist; it is safe to call concurrently with other
+// goroutines recording into hist, and with recording into any of others.
 func (hist *LogHistogram) AddHistograms(others ...*LogHistogram) error {
 	for _, other := range others {
 		if other.min != hist.min || other.max != hist.max || len(other.count) != len(hist.count) {
 			return errors.New("can only merge histograms with identical bounds and precision")
 		}
-		for i, k := range other.count {
-			hist.count[i] += k
+		for i := range other.count {
+			atomic.AddUint64(&hist.count[i], atomic.LoadUint64(&other.count[i]))
 		}
 	}
 	return nil
@@ -0,0 +1,208 @@
+package tdigest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// logHistogramBinaryVersion is written as the first byte of the binary encoding so that
+// future format changes can be detected by UnmarshalBinary.
+const logHistogramBinaryVersion = 1
+
+// MarshalBinary encodes min, max, logFactor, logOffset and the bucket counts, followed
+// by a CRC32 checksum of everything that precedes it. The counts are delta-and-zigzag
+// varint encoded, which is compact because most bins are zero or close to their
+// neighbor in a typical LogHistogram.
+func (hist LogHistogram) MarshalBinary() ([]byte, error) {
+	counts := hist.Snapshot()
+
+	var buf bytes.Buffer
+	buf.WriteByte(logHistogramBinaryVersion)
+
+	var f [8]byte
+	putFloat := func(v float64) {
+		binary.LittleEndian.PutUint64(f[:], math.Float64bits(v))
+		buf.Write(f[:])
+	}
+	putFloat(hist.min)
+	putFloat(hist.max)
+	putFloat(hist.logFactor)
+	putFloat(hist.logOffset)
+
+	var v [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(v[:], uint64(len(counts)))
+	buf.Write(v[:n])
+
+	var prev int64
+	for _, c := range counts {
+		n := binary.PutVarint(v[:], int64(c)-prev)
+		buf.Write(v[:n])
+		prev = int64(c)
+	}
+
+	checksum := crc32.ChecksumIEEE(buf.Bytes())
+	binary.LittleEndian.PutUint32(f[:4], checksum)
+	buf.Write(f[:4])
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a LogHistogram previously encoded with MarshalBinary.
+func (hist *LogHistogram) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+8*4+4 {
+		return errors.New("LogHistogram: binary data too short")
+	}
+
+	payload, checksum := data[:len(data)-4], data[len(data)-4:]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(checksum) {
+		return errors.New("LogHistogram: checksum mismatch")
+	}
+
+	r := bytes.NewReader(payload)
+	version, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != logHistogramBinaryVersion {
+		return fmt.Errorf("LogHistogram: unsupported binary version %d", version)
+	}
+
+	var f [8]byte
+	readFloat := func() (float64, error) {
+		if _, err := io.ReadFull(r, f[:]); err != nil {
+			return 0, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(f[:])), nil
+	}
+	min, err := readFloat()
+	if err != nil {
+		return err
+	}
+	max, err := readFloat()
+	if err != nil {
+		return err
+	}
+	logFactor, err := readFloat()
+	if err != nil {
+		return err
+	}
+	logOffset, err := readFloat()
+	if err != nil {
+		return err
+	}
+
+	binCount, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if binCount > logHistogramMaxBins {
+		return fmt.Errorf("LogHistogram: %d bins exceeds the maximum of %d", binCount, logHistogramMaxBins)
+	}
+	count := make([]uint64, binCount)
+	var prev int64
+	for i := range count {
+		delta, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		prev += delta
+		count[i] = uint64(prev)
+	}
+
+	hist.min, hist.max, hist.logFactor, hist.logOffset = min, max, logFactor, logOffset
+	hist.count = count
+	return nil
+}
+
+// logHistogramJSON is the wire shape used by MarshalJSON/UnmarshalJSON.
+type logHistogramJSON struct {
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	LogFactor float64  `json:"logFactor"`
+	LogOffset float64  `json:"logOffset"`
+	Count     []uint64 `json:"count"`
+}
+
+// MarshalJSON encodes the histogram's bounds and bucket counts as JSON.
+func (hist LogHistogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(logHistogramJSON{
+		Min:       hist.min,
+		Max:       hist.max,
+		LogFactor: hist.logFactor,
+		LogOffset: hist.logOffset,
+		Count:     hist.Snapshot(),
+	})
+}
+
+// UnmarshalJSON decodes a LogHistogram previously encoded with MarshalJSON.
+func (hist *LogHistogram) UnmarshalJSON(data []byte) error {
+	var j logHistogramJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if len(j.Count) > logHistogramMaxBins {
+		return fmt.Errorf("LogHistogram: %d bins exceeds the maximum of %d", len(j.Count), logHistogramMaxBins)
+	}
+	hist.min, hist.max, hist.logFactor, hist.logOffset = j.Min, j.Max, j.LogFactor, j.LogOffset
+	hist.count = j.Count
+	return nil
+}
+
+// WriteOpenMetrics writes hist to w as a Prometheus/OpenMetrics cumulative histogram:
+// one "<name>_bucket" line per bin, with le set to the bin's upper bound
+// (LowerBound(i+1)), a final "<name>_bucket" line with le="+Inf" as required by the
+// OpenMetrics exposition format, followed by "<name>_sum" and "<name>_count" lines.
+// labels, if non-empty, are attached to every line. This lets a LogHistogram be scraped
+// directly without a separate conversion step.
+func (hist LogHistogram) WriteOpenMetrics(w io.Writer, name string, labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labelString := func(le string) string {
+		parts := make([]string, 0, len(keys)+1)
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+		}
+		if le != "" {
+			parts = append(parts, fmt.Sprintf("le=%q", le))
+		}
+		if len(parts) == 0 {
+			return ""
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	}
+
+	var cumulative uint64
+	var sum float64
+	for i, c := range hist.Snapshot() {
+		cumulative += c
+		lower, upper := hist.LowerBound(i), hist.LowerBound(i+1)
+		sum += 0.5 * (lower + upper) * float64(c)
+		le := strconv.FormatFloat(upper, 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelString(le), cumulative); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelString("+Inf"), cumulative); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s_sum%s %g\n", name, labelString(""), sum); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s_count%s %d\n", name, labelString(""), cumulative); err != nil {
+		return err
+	}
+	return nil
+}
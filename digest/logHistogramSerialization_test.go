@@ -0,0 +1,158 @@
+package tdigest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogHistogram_BinaryRoundTrip(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	for x := 0.05; x < 2000; x *= 1.3 {
+		hist.Add(x)
+	}
+
+	data, err := hist.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var roundTripped LogHistogram
+	if err := roundTripped.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	before, after := hist.GetCounts(), roundTripped.GetCounts()
+	if len(before) != len(after) {
+		t.Fatalf("bucket count mismatch: %d vs %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("count[%d] = %d, want %d", i, after[i], before[i])
+		}
+	}
+
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	var victim LogHistogram
+	if err := victim.UnmarshalBinary(corrupt); err == nil {
+		t.Error("expected checksum mismatch on corrupted data")
+	}
+}
+
+func TestLogHistogram_UnmarshalBinary_RejectsOversizedBinCount(t *testing.T) {
+	var payload bytes.Buffer
+	payload.WriteByte(logHistogramBinaryVersion)
+	var f [8]byte
+	for i := 0; i < 4; i++ {
+		payload.Write(f[:]) // min, max, logFactor, logOffset, all zero
+	}
+	var v [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(v[:], logHistogramMaxBins+1)
+	payload.Write(v[:n])
+
+	data := payload.Bytes()
+	var crcBuf [4]byte
+	binary.LittleEndian.PutUint32(crcBuf[:], crc32.ChecksumIEEE(data))
+	data = append(data, crcBuf[:]...)
+
+	var victim LogHistogram
+	if err := victim.UnmarshalBinary(data); err == nil {
+		t.Error("expected UnmarshalBinary to reject a binCount above logHistogramMaxBins")
+	}
+}
+
+func TestLogHistogram_JSONRoundTrip(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	hist.Add(1)
+	hist.Add(100)
+
+	data, err := hist.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var roundTripped LogHistogram
+	if err := roundTripped.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+
+	before, after := hist.GetCounts(), roundTripped.GetCounts()
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("count[%d] = %d, want %d", i, after[i], before[i])
+		}
+	}
+}
+
+func TestLogHistogram_WriteOpenMetrics(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	hist.Add(1)
+	hist.Add(100)
+
+	var buf bytes.Buffer
+	if err := hist.WriteOpenMetrics(&buf, "request_latency", map[string]string{"service": "api"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `request_latency_bucket{service="api",le="`) {
+		t.Errorf("missing bucket lines with labels: %s", out)
+	}
+	if !strings.Contains(out, `request_latency_bucket{service="api",le="+Inf"} 2`+"\n") {
+		t.Errorf("missing required +Inf bucket line: %s", out)
+	}
+	if !strings.Contains(out, "request_latency_sum{service=\"api\"}") {
+		t.Errorf("missing sum line: %s", out)
+	}
+	if !strings.Contains(out, "request_latency_count{service=\"api\"} 2\n") {
+		t.Errorf("expected count of 2, got: %s", out)
+	}
+}
+
+// TestLogHistogram_ConcurrentMarshal exercises MarshalBinary, MarshalJSON and
+// WriteOpenMetrics concurrently with Add, to catch the data race that slipped in when
+// those methods read hist.count directly instead of going through Snapshot().
+func TestLogHistogram_ConcurrentMarshal(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+
+	const iterations = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.Add(1.0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			if _, err := hist.MarshalBinary(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			if _, err := hist.MarshalJSON(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			if err := hist.WriteOpenMetrics(io.Discard, "latency", nil); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,200 @@
+package tdigest
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// Count returns the total number of values recorded.
+func (hist LogHistogram) Count() uint64 {
+	var n uint64
+	for _, c := range hist.Snapshot() {
+		n += c
+	}
+	return n
+}
+
+// Min returns the lower bound the histogram was constructed with.
+func (hist LogHistogram) Min() float64 {
+	return hist.min
+}
+
+// Max returns the upper bound the histogram was constructed with.
+func (hist LogHistogram) Max() float64 {
+	return hist.max
+}
+
+// midpoint returns the geometric mean of bin i's lower and upper bounds, used as a
+// stand-in for the values actually recorded in that bin.
+func (hist LogHistogram) midpoint(i int) float64 {
+	return math.Sqrt(hist.LowerBound(i) * hist.LowerBound(i+1))
+}
+
+// Mean returns the mean of the recorded values, approximated from bin midpoints.
+func (hist LogHistogram) Mean() float64 {
+	var sum float64
+	var n uint64
+	for i, c := range hist.Snapshot() {
+		if c == 0 {
+			continue
+		}
+		sum += hist.midpoint(i) * float64(c)
+		n += c
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// CDF returns the estimated fraction of recorded values that are <= x. Within a bin,
+// values are assumed to be spread uniformly in log space between its bounds.
+func (hist LogHistogram) CDF(x float64) float64 {
+	counts := hist.Snapshot()
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	if x <= hist.min {
+		return 0
+	}
+	if x >= hist.max {
+		return 1
+	}
+
+	var below float64
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		lower, upper := hist.LowerBound(i), hist.LowerBound(i+1)
+		switch {
+		case upper <= x:
+			below += float64(c)
+		case lower < x:
+			below += float64(c) * logSpaceFraction(lower, upper, x)
+		}
+	}
+	return below / float64(total)
+}
+
+// Quantile returns an estimate of the q'th quantile (0 <= q <= 1) of the recorded
+// values, found by locating the bin q falls into and interpolating within it in log
+// space.
+func (hist LogHistogram) Quantile(q float64) float64 {
+	counts := hist.Snapshot()
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return hist.min
+	}
+	if q >= 1 {
+		return hist.max
+	}
+
+	target := q * float64(total)
+	var cumulative float64
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if cumulative+float64(c) >= target {
+			lower, upper := hist.LowerBound(i), hist.LowerBound(i+1)
+			frac := (target - cumulative) / float64(c)
+			return math.Exp(math.Log(lower) + frac*(math.Log(upper)-math.Log(lower)))
+		}
+		cumulative += float64(c)
+	}
+	return hist.max
+}
+
+// logSpaceFraction returns the fraction of [lower, upper) that lies at or below x,
+// measured in log space, assuming lower < x < upper.
+func logSpaceFraction(lower, upper, x float64) float64 {
+	return (math.Log(x) - math.Log(lower)) / (math.Log(upper) - math.Log(lower))
+}
+
+// MergeInto adds hist's recorded values into dst, redistributing each of hist's bin
+// counts across dst's bins in proportion to their overlap in log space. Unlike
+// AddHistograms, hist and dst need not share the same min, max or epsilonFactor, which
+// makes this the right choice for aggregating histograms gathered from services that
+// were not configured identically.
+func (hist LogHistogram) MergeInto(dst *LogHistogram) {
+	for i, c := range hist.Snapshot() {
+		if c == 0 {
+			continue
+		}
+		lower, upper := hist.LowerBound(i), hist.LowerBound(i+1)
+		distributeLogRange(dst, lower, upper, float64(c))
+	}
+}
+
+// distributeLogRange adds weight (always an integer value; it is a bin count cast to
+// float64) to dst, split across the dst bins that overlap [lower, upper) in proportion
+// to each bin's share of that interval in log space. The per-bin shares are apportioned
+// with the largest-remainder method so that the total added across all of dst's bins is
+// exactly weight: rounding each bin's share independently would, for a source bin whose
+// weight spans many narrow destination bins, round every share down to zero and lose
+// the count entirely.
+func distributeLogRange(dst *LogHistogram, lower, upper, weight float64) {
+	total := uint64(math.Round(weight))
+	if total == 0 {
+		return
+	}
+
+	startIdx, endIdx := dst.bucket(lower), dst.bucket(upper)
+	if endIdx < startIdx {
+		startIdx, endIdx = endIdx, startIdx
+	}
+	if startIdx == endIdx || upper <= lower {
+		atomic.AddUint64(&dst.count[startIdx], total)
+		return
+	}
+
+	logLower, logUpper := math.Log(lower), math.Log(upper)
+	totalLog := logUpper - logLower
+
+	type bucketShare struct {
+		idx       int
+		remainder float64
+	}
+	shares := make([]bucketShare, 0, endIdx-startIdx+1)
+	var floorSum uint64
+	for idx := startIdx; idx <= endIdx; idx++ {
+		binLower, binUpper := dst.LowerBound(idx), dst.LowerBound(idx+1)
+		overlapLower := math.Max(logLower, math.Log(binLower))
+		overlapUpper := math.Min(logUpper, math.Log(binUpper))
+		if overlapUpper <= overlapLower {
+			continue
+		}
+		exact := float64(total) * (overlapUpper - overlapLower) / totalLog
+		floor := uint64(exact)
+		floorSum += floor
+		atomic.AddUint64(&dst.count[idx], floor)
+		shares = append(shares, bucketShare{idx: idx, remainder: exact - float64(floor)})
+	}
+
+	remaining := total - floorSum
+	if remaining == 0 {
+		return
+	}
+	if len(shares) == 0 {
+		// No bin had a positive overlap (can happen at the floating-point edges of the
+		// range); fall back to crediting it all to the first bin touched.
+		atomic.AddUint64(&dst.count[startIdx], remaining)
+		return
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].remainder > shares[j].remainder })
+	for i := uint64(0); i < remaining; i++ {
+		atomic.AddUint64(&dst.count[shares[i%uint64(len(shares))].idx], 1)
+	}
+}
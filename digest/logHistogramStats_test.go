@@ -0,0 +1,122 @@
+package tdigest
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestLogHistogram_QuantileAndCDF(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.01)
+	for x := 1.0; x <= 100; x++ {
+		hist.Add(x)
+	}
+
+	if count := hist.Count(); count != 100 {
+		t.Errorf("expected Count() == 100, got %d", count)
+	}
+
+	median := hist.Quantile(0.5)
+	if median < 45 || median > 55 {
+		t.Errorf("expected median near 50, got %.2f", median)
+	}
+
+	if cdf := hist.CDF(hist.Quantile(0.5)); math.Abs(cdf-0.5) > 0.05 {
+		t.Errorf("expected CDF(Quantile(0.5)) near 0.5, got %.3f", cdf)
+	}
+	if hist.CDF(hist.Min()) != 0 {
+		t.Errorf("expected CDF(Min()) == 0, got %.3f", hist.CDF(hist.Min()))
+	}
+	if hist.CDF(hist.Max()) != 1 {
+		t.Errorf("expected CDF(Max()) == 1, got %.3f", hist.CDF(hist.Max()))
+	}
+}
+
+func TestLogHistogram_Mean(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.01)
+	hist.Add(10)
+	hist.Add(10)
+
+	if mean := hist.Mean(); math.Abs(mean-10) > 1 {
+		t.Errorf("expected Mean() near 10, got %.2f", mean)
+	}
+}
+
+func TestLogHistogram_MergeInto(t *testing.T) {
+	src, _ := NewLogHistogram(1, 1e6, 0.1)
+	for x := 1.0; x < 1e6; x *= 1.7 {
+		src.Add(x)
+	}
+
+	dst, _ := NewLogHistogram(0.1, 1e7, 0.2)
+	src.MergeInto(dst)
+
+	if dst.Count() != src.Count() {
+		t.Errorf("expected MergeInto to preserve total count, got %d want %d", dst.Count(), src.Count())
+	}
+	if mean, srcMean := dst.Mean(), src.Mean(); math.Abs(mean-srcMean)/srcMean > 0.2 {
+		t.Errorf("expected merged mean close to source mean, got %.2f want ~%.2f", mean, srcMean)
+	}
+}
+
+// TestLogHistogram_MergeIntoFinerDestination merges into a dst with much narrower bins
+// than src, so a single src bin's count must be split across several dst bins. That
+// split must not lose counts to independent per-bin rounding.
+func TestLogHistogram_MergeIntoFinerDestination(t *testing.T) {
+	src, _ := NewLogHistogram(0.1, 1000, 0.3)
+	for i := 0; i < 25; i++ {
+		src.Add(10)
+	}
+
+	dst, _ := NewLogHistogram(0.1, 1000, 0.01)
+	src.MergeInto(dst)
+
+	if dst.Count() != src.Count() {
+		t.Errorf("expected MergeInto into a finer histogram to preserve total count, got %d want %d", dst.Count(), src.Count())
+	}
+}
+
+// TestLogHistogram_ConcurrentStats exercises Count, Mean, CDF, Quantile and MergeInto
+// concurrently with Add, to catch the data race that slipped in when those methods read
+// hist.count directly instead of going through Snapshot().
+func TestLogHistogram_ConcurrentStats(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	dst, _ := NewLogHistogram(0.1, 1000, 0.1)
+
+	const iterations = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(5)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.Add(1.0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.Count()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.Mean()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.CDF(10)
+			hist.Quantile(0.5)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			hist.MergeInto(dst)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,75 @@
+package tdigest
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogHistogram_ConcurrentAdd(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+
+	const goroutines = 50
+	const perGoroutine = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				hist.Add(1.0)
+				hist.RecordDuration(time.Second)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total uint64
+	for _, k := range hist.Snapshot() {
+		total += k
+	}
+	if want := uint64(goroutines * perGoroutine * 2); total != want {
+		t.Errorf("expected %d total counts after concurrent Add, got %d", want, total)
+	}
+}
+
+func TestLogHistogram_ConcurrentAddHistograms(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	other, _ := NewLogHistogram(0.1, 1000, 0.1)
+
+	const goroutines = 50
+	const perGoroutine = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines + 1)
+	for g := 0; g < goroutines; g++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				other.Add(1.0)
+			}
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		for i := 0; i < perGoroutine; i++ {
+			hist.AddHistograms(other)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestLogHistogram_Reset(t *testing.T) {
+	hist, _ := NewLogHistogram(0.1, 1000, 0.1)
+	hist.Add(1.0)
+	hist.Add(10.0)
+
+	hist.Reset()
+
+	for i, k := range hist.Snapshot() {
+		if k != 0 {
+			t.Errorf("expected bucket %d to be zero after Reset, got %d", i, k)
+		}
+	}
+}